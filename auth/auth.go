@@ -0,0 +1,32 @@
+/*
+ *
+ */
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnauthenticated is returned by an Authenticator when the request
+// carries no usable credentials, or they don't check out.
+var ErrUnauthenticated = errors.New("auth: invalid or missing credentials")
+
+// errRateLimited is returned to the caller when a per-subject rate limit has been exceeded.
+var errRateLimited = errors.New("auth: rate limit exceeded")
+
+// Authenticator validates the credentials carried by an inbound request
+// and identifies the caller they belong to.
+type Authenticator interface {
+	// Authenticate returns a caller identifier (e.g. the bearer token
+	// itself, or a JWT subject claim) used for rate limiting, or
+	// ErrUnauthenticated if req is not authenticated.
+	Authenticate(req *http.Request) (subject string, err error)
+}
+
+// httpError mirrors the shape of the server's HttpError type so auth
+// responses look the same as the rest of the API without this package
+// importing main.
+type httpError struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,88 @@
+/*
+ *
+ */
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func signedRequest(secret, callerID string, body []byte) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(callerID))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/message", bytes.NewReader(body))
+	req.Header.Set("X-Caller-Id", callerID)
+	req.Header.Set("X-Signature", signature)
+	return req
+}
+
+func TestHMACAuthenticatorAcceptsValidSignature(t *testing.T) {
+	a := NewHMACAuthenticator("s3cret")
+	req := signedRequest("s3cret", "caller-1", []byte(`{"token":"abc"}`))
+
+	subject, err := a.Authenticate(req)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if subject != "caller-1" {
+		t.Fatalf("expected subject %q, got %q", "caller-1", subject)
+	}
+
+	// Body must still be readable by downstream handlers.
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("reading restored body: %v", err)
+	}
+	if string(body) != `{"token":"abc"}` {
+		t.Fatalf("expected body to be restored, got %q", body)
+	}
+}
+
+func TestHMACAuthenticatorRejectsWrongSecret(t *testing.T) {
+	a := NewHMACAuthenticator("s3cret")
+	req := signedRequest("wrong-secret", "caller-1", []byte(`{"token":"abc"}`))
+
+	if _, err := a.Authenticate(req); err != ErrUnauthenticated {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+}
+
+func TestHMACAuthenticatorRejectsTamperedCallerID(t *testing.T) {
+	a := NewHMACAuthenticator("s3cret")
+	req := signedRequest("s3cret", "caller-1", []byte(`{"token":"abc"}`))
+	req.Header.Set("X-Caller-Id", "caller-2")
+
+	if _, err := a.Authenticate(req); err != ErrUnauthenticated {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+}
+
+func TestHMACAuthenticatorRejectsMissingCallerID(t *testing.T) {
+	a := NewHMACAuthenticator("s3cret")
+	req := signedRequest("s3cret", "caller-1", []byte(`{"token":"abc"}`))
+	req.Header.Del("X-Caller-Id")
+
+	if _, err := a.Authenticate(req); err != ErrUnauthenticated {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+}
+
+func TestHMACAuthenticatorRejectsMissingSignature(t *testing.T) {
+	a := NewHMACAuthenticator("s3cret")
+	req := httptest.NewRequest(http.MethodPost, "/message", bytes.NewReader(nil))
+	req.Header.Set("X-Caller-Id", "caller-1")
+
+	if _, err := a.Authenticate(req); err != ErrUnauthenticated {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ *
+ */
+package auth
+
+import (
+	"net/http"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTAuthenticator authenticates requests carrying a JWT bearer token
+// signed by a key published on a JWKS endpoint.
+type JWTAuthenticator struct {
+	jwks *keyfunc.JWKS
+}
+
+// NewJWTAuthenticator builds a JWTAuthenticator that validates tokens
+// against the keys published at jwksURL, refreshing them in the
+// background as they rotate.
+func NewJWTAuthenticator(jwksURL string) (*JWTAuthenticator, error) {
+	jwks, err := keyfunc.Get(jwksURL, keyfunc.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &JWTAuthenticator{jwks: jwks}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(req *http.Request) (string, error) {
+	tokenString := bearerToken(req)
+	if tokenString == "" {
+		return "", ErrUnauthenticated
+	}
+
+	token, err := jwt.Parse(tokenString, a.jwks.Keyfunc)
+	if err != nil || !token.Valid {
+		return "", ErrUnauthenticated
+	}
+
+	subject, err := token.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return "", ErrUnauthenticated
+	}
+	return subject, nil
+}
@@ -0,0 +1,37 @@
+/*
+ *
+ */
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Middleware builds a mux.MiddlewareFunc-compatible handler that rejects
+// requests failing authenticator with 401, and requests exceeding limiter
+// with 429, before delegating to next.
+func Middleware(authenticator Authenticator, limiter *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			subject, err := authenticator.Authenticate(req)
+			if err != nil {
+				writeError(writer, http.StatusUnauthorized, err)
+				return
+			}
+
+			if limiter != nil && !limiter.Allow(subject) {
+				writeError(writer, http.StatusTooManyRequests, errRateLimited)
+				return
+			}
+
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+func writeError(writer http.ResponseWriter, status int, err error) {
+	writer.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(&httpError{Error: err.Error()})
+}
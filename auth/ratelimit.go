@@ -0,0 +1,47 @@
+/*
+ *
+ */
+package auth
+
+import (
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter enforces a per-subject token-bucket rate limit, e.g. one
+// bucket per authenticated caller.
+type RateLimiter struct {
+	rps   rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimiter builds a RateLimiter allowing rps requests per second per
+// subject, with bursts of up to burst requests.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rps:      rate.Limit(rps),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// Allow reports whether a request from subject is allowed to proceed now.
+func (l *RateLimiter) Allow(subject string) bool {
+	return l.limiterFor(subject).Allow()
+}
+
+func (l *RateLimiter) limiterFor(subject string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[subject]
+	if !ok {
+		limiter = rate.NewLimiter(l.rps, l.burst)
+		l.limiters[subject] = limiter
+	}
+	return limiter
+}
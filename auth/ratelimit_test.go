@@ -0,0 +1,33 @@
+/*
+ *
+ */
+package auth
+
+import "testing"
+
+func TestRateLimiterAllowsUpToBurstThenBlocks(t *testing.T) {
+	limiter := NewRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("caller-1") {
+			t.Fatalf("expected request %d within burst to be allowed", i)
+		}
+	}
+	if limiter.Allow("caller-1") {
+		t.Fatal("expected request beyond burst to be denied")
+	}
+}
+
+func TestRateLimiterTracksSubjectsIndependently(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+
+	if !limiter.Allow("caller-1") {
+		t.Fatal("expected first request from caller-1 to be allowed")
+	}
+	if limiter.Allow("caller-1") {
+		t.Fatal("expected second request from caller-1 to be denied")
+	}
+	if !limiter.Allow("caller-2") {
+		t.Fatal("expected caller-2's bucket to be independent of caller-1's")
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ *
+ */
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// StaticTokenAuthenticator authenticates requests carrying one of a fixed
+// set of bearer tokens in the Authorization header, e.g. configured via
+// the AUTH_TOKENS environment variable.
+type StaticTokenAuthenticator struct {
+	tokens map[string]struct{}
+}
+
+// NewStaticTokenAuthenticator builds a StaticTokenAuthenticator accepting
+// any of the given tokens.
+func NewStaticTokenAuthenticator(tokens []string) *StaticTokenAuthenticator {
+	set := make(map[string]struct{}, len(tokens))
+	for _, t := range tokens {
+		set[t] = struct{}{}
+	}
+	return &StaticTokenAuthenticator{tokens: set}
+}
+
+func (a *StaticTokenAuthenticator) Authenticate(req *http.Request) (string, error) {
+	token := bearerToken(req)
+	if token == "" {
+		return "", ErrUnauthenticated
+	}
+	if _, ok := a.tokens[token]; !ok {
+		return "", ErrUnauthenticated
+	}
+	return token, nil
+}
+
+func bearerToken(req *http.Request) string {
+	header := req.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
@@ -0,0 +1,69 @@
+/*
+ *
+ */
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rm3l/fcm-push-notification-app-server/fcm"
+	"github.com/rm3l/fcm-push-notification-app-server/metrics"
+)
+
+// MaxMessages is the maximum number of messages FCM accepts in a single
+// batch send, mirroring the limit enforced by the Firebase Admin SDK's
+// SendAll/SendMulticast.
+const MaxMessages = 500
+
+// Response reports the outcome of sending a single message within a batch,
+// keyed by its position in the original request.
+type Response struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	Name    string `json:"name,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Send dispatches messages to FCM concurrently, bounded by concurrency
+// workers, and returns one Response per message in the same order they
+// were given. A failure sending one message does not prevent the others
+// from being attempted.
+func Send(ctx context.Context, client *fcm.Client, messages []fcm.Message, concurrency int) ([]Response, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("batch: no messages to send")
+	}
+	if len(messages) > MaxMessages {
+		return nil, fmt.Errorf("batch: at most %d messages allowed per request, got %d", MaxMessages, len(messages))
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]Response, len(messages))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, msg := range messages {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, msg fcm.Message) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			res, err := client.Send(ctx, msg)
+			metrics.ObserveSend("batch", time.Since(start), err)
+			if err != nil {
+				results[i] = Response{Index: i, Success: false, Error: err.Error()}
+				return
+			}
+			results[i] = Response{Index: i, Success: true, Name: res.Name}
+		}(i, msg)
+	}
+
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,119 @@
+/*
+ *
+ */
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const sendURLFormat = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+
+// messagingScope is the OAuth2 scope required to call the FCM v1 API.
+var messagingScope = []string{"https://www.googleapis.com/auth/firebase.messaging"}
+
+// Client sends messages to Firebase Cloud Messaging using the HTTP v1 API.
+// It caches and refreshes its own OAuth2 access token via a token source
+// backed by a service-account JSON file.
+type Client struct {
+	httpClient  *http.Client
+	projectID   string
+	tokenSource oauth2.TokenSource
+	sendURL     string
+}
+
+// NewClient builds a Client authenticated with the service-account
+// credentials found at credentialsFile, scoped to send messages for
+// projectID.
+func NewClient(ctx context.Context, credentialsFile string, projectID string) (*Client, error) {
+	credsJSON, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: reading credentials file: %w", err)
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, credsJSON, messagingScope...)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: parsing service account credentials: %w", err)
+	}
+
+	return &Client{
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		projectID:   projectID,
+		tokenSource: oauth2.ReuseTokenSource(nil, creds.TokenSource),
+		sendURL:     fmt.Sprintf(sendURLFormat, projectID),
+	}, nil
+}
+
+// Ready reports whether the client can currently acquire an OAuth2 access
+// token, used as a readiness check by callers.
+func (c *Client) Ready(ctx context.Context) error {
+	_, err := c.tokenSource.Token()
+	if err != nil {
+		return fmt.Errorf("fcm: acquiring OAuth2 token: %w", err)
+	}
+	return nil
+}
+
+// Send delivers msg through the FCM v1 `messages:send` endpoint and
+// returns the message name FCM assigned on success.
+func (c *Client) Send(ctx context.Context, msg Message) (*SendResponse, error) {
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("fcm: acquiring OAuth2 token: %w", err)
+	}
+
+	body, err := json.Marshal(sendRequest{Message: msg})
+	if err != nil {
+		return nil, fmt.Errorf("fcm: marshaling message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.sendURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("fcm: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: sending message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		fcmErr := &Error{HTTPStatus: resp.StatusCode}
+		if jsonErr := json.Unmarshal(respBody, &errResp); jsonErr == nil {
+			fcmErr.Status = errResp.Error.Status
+			fcmErr.Message = errResp.Error.Message
+			for _, d := range errResp.Error.Details {
+				if d.ErrorCode != "" {
+					fcmErr.ErrorCode = d.ErrorCode
+				}
+			}
+		} else {
+			fcmErr.Message = string(respBody)
+		}
+		return nil, fcmErr
+	}
+
+	var sendResp SendResponse
+	if err := json.Unmarshal(respBody, &sendResp); err != nil {
+		return nil, fmt.Errorf("fcm: decoding response: %w", err)
+	}
+	return &sendResp, nil
+}
@@ -0,0 +1,74 @@
+/*
+ *
+ */
+package fcm
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// errorResponse mirrors the error envelope returned by FCM's v1 API
+// (google.rpc.Status, as documented for the HTTP v1 send endpoint).
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// Error represents a failure returned by FCM when sending a message.
+type Error struct {
+	// HTTPStatus is the status code returned by the FCM API itself.
+	HTTPStatus int
+	// Status is FCM's canonical error status, e.g. "NOT_FOUND" or "UNAVAILABLE".
+	Status string
+	// ErrorCode is FCM's fine-grained error code, e.g. "UNREGISTERED" or "INVALID_ARGUMENT".
+	ErrorCode string
+	// Message is the human-readable message returned by FCM.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fcm: %s (%s): %s", e.Status, e.ErrorCode, e.Message)
+}
+
+// Retryable reports whether the send that produced this error is worth retrying.
+func (e *Error) Retryable() bool {
+	switch e.Status {
+	case "UNAVAILABLE", "INTERNAL":
+		return true
+	}
+	return e.HTTPStatus == http.StatusTooManyRequests || e.HTTPStatus >= http.StatusInternalServerError
+}
+
+// ResponseStatus maps an FCM error to the HTTP status code the caller of
+// this server should see.
+func ResponseStatus(err error) int {
+	fcmErr, ok := err.(*Error)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch fcmErr.Status {
+	case "INVALID_ARGUMENT":
+		return http.StatusBadRequest
+	case "UNREGISTERED", "NOT_FOUND":
+		return http.StatusNotFound
+	case "PERMISSION_DENIED", "UNAUTHENTICATED", "SENDER_ID_MISMATCH":
+		return http.StatusForbidden
+	case "QUOTA_EXCEEDED":
+		return http.StatusTooManyRequests
+	case "UNAVAILABLE":
+		return http.StatusServiceUnavailable
+	default:
+		if fcmErr.HTTPStatus != 0 {
+			return fcmErr.HTTPStatus
+		}
+		return http.StatusInternalServerError
+	}
+}
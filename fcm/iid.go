@@ -0,0 +1,108 @@
+/*
+ *
+ */
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	iidBatchAddURL    = "https://iid.googleapis.com/iid/v1:batchAdd"
+	iidBatchRemoveURL = "https://iid.googleapis.com/iid/v1:batchRemove"
+)
+
+// TokenResult reports the outcome of a subscribe/unsubscribe call for a
+// single registration token, in the same order the tokens were submitted.
+type TokenResult struct {
+	Token string `json:"token"`
+	Error string `json:"error,omitempty"`
+}
+
+// iidBatchRequest is the body expected by the Instance ID batchAdd/batchRemove endpoints.
+type iidBatchRequest struct {
+	To                 string   `json:"to"`
+	RegistrationTokens []string `json:"registration_tokens"`
+}
+
+// iidBatchResponse is the body returned by the Instance ID batchAdd/batchRemove endpoints.
+type iidBatchResponse struct {
+	Results []struct {
+		Error string `json:"error,omitempty"`
+	} `json:"results"`
+}
+
+// SubscribeToTopic subscribes tokens to topic via FCM's Instance ID batchAdd API.
+func (c *Client) SubscribeToTopic(ctx context.Context, topic string, tokens []string) ([]TokenResult, error) {
+	return c.iidBatch(ctx, iidBatchAddURL, topic, tokens)
+}
+
+// UnsubscribeFromTopic unsubscribes tokens from topic via FCM's Instance ID batchRemove API.
+func (c *Client) UnsubscribeFromTopic(ctx context.Context, topic string, tokens []string) ([]TokenResult, error) {
+	return c.iidBatch(ctx, iidBatchRemoveURL, topic, tokens)
+}
+
+func (c *Client) iidBatch(ctx context.Context, url string, topic string, tokens []string) ([]TokenResult, error) {
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("fcm: acquiring OAuth2 token: %w", err)
+	}
+
+	body, err := json.Marshal(iidBatchRequest{
+		To:                 "/topics/" + topic,
+		RegistrationTokens: tokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fcm: marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("fcm: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("access_token_auth", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		fcmErr := &Error{HTTPStatus: resp.StatusCode}
+		if jsonErr := json.Unmarshal(respBody, &errResp); jsonErr == nil {
+			fcmErr.Status = errResp.Error.Status
+			fcmErr.Message = errResp.Error.Message
+		} else {
+			fcmErr.Message = string(respBody)
+		}
+		return nil, fcmErr
+	}
+
+	var batchResp iidBatchResponse
+	if err := json.Unmarshal(respBody, &batchResp); err != nil {
+		return nil, fmt.Errorf("fcm: decoding response: %w", err)
+	}
+
+	results := make([]TokenResult, len(tokens))
+	for i, tok := range tokens {
+		results[i] = TokenResult{Token: tok}
+		if i < len(batchResp.Results) {
+			results[i].Error = batchResp.Results[i].Error
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,65 @@
+/*
+ *
+ */
+package fcm
+
+// Message is the payload accepted by the FCM HTTP v1 `messages:send` endpoint.
+// Exactly one of Token, Topic or Condition should be set to address the message.
+type Message struct {
+	Token        string            `json:"token,omitempty"`
+	Topic        string            `json:"topic,omitempty"`
+	Condition    string            `json:"condition,omitempty"`
+	Notification *Notification     `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      *AndroidConfig    `json:"android,omitempty"`
+	APNS         *APNSConfig       `json:"apns,omitempty"`
+	Webpush      *WebpushConfig    `json:"webpush,omitempty"`
+}
+
+// Notification is the basic, cross-platform notification payload.
+type Notification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+// AndroidConfig holds Android-specific delivery options.
+type AndroidConfig struct {
+	CollapseKey  string               `json:"collapse_key,omitempty"`
+	Priority     string               `json:"priority,omitempty"`
+	TTL          string               `json:"ttl,omitempty"`
+	Notification *AndroidNotification `json:"notification,omitempty"`
+}
+
+// AndroidNotification holds Android-specific notification display options.
+type AndroidNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+	Color string `json:"color,omitempty"`
+	Sound string `json:"sound,omitempty"`
+}
+
+// APNSConfig holds Apple Push Notification Service-specific delivery options.
+type APNSConfig struct {
+	Headers map[string]string      `json:"headers,omitempty"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// WebpushConfig holds Web Push-specific delivery options.
+type WebpushConfig struct {
+	Headers      map[string]string      `json:"headers,omitempty"`
+	Data         map[string]string      `json:"data,omitempty"`
+	Notification map[string]interface{} `json:"notification,omitempty"`
+}
+
+// sendRequest is the envelope FCM's `messages:send` endpoint expects.
+type sendRequest struct {
+	ValidateOnly bool    `json:"validate_only,omitempty"`
+	Message      Message `json:"message"`
+}
+
+// SendResponse is returned by FCM on a successful send.
+type SendResponse struct {
+	Name string `json:"name"`
+}
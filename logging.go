@@ -0,0 +1,94 @@
+/*
+ *
+ */
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type correlationIDKey struct{}
+
+// initLogger configures the global zerolog logger according to cfg's
+// LogLevel and LogFormat, and returns the configured logger.
+func initLogger(cfg ServerConfig) zerolog.Logger {
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
+
+	var writer = zerolog.ConsoleWriter{}
+	logger := log.Logger
+	if cfg.LogFormat == "console" {
+		logger = zerolog.New(writer).With().Timestamp().Logger()
+	}
+	log.Logger = logger
+	return logger
+}
+
+// loggerFromRequest returns a logger enriched with the request's correlation ID.
+func loggerFromRequest(req *http.Request) *zerolog.Logger {
+	correlationID, _ := req.Context().Value(correlationIDKey{}).(string)
+	logger := log.With().Str("correlation_id", correlationID).Logger()
+	return &logger
+}
+
+// correlationIDMiddleware assigns each request a correlation ID (reusing one
+// supplied via the X-Request-Id header, if any) and makes it available both
+// on the response and in the request context for logging.
+func correlationIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		correlationID := req.Header.Get("X-Request-Id")
+		if correlationID == "" {
+			correlationID = uuid.NewString()
+		}
+		writer.Header().Set("X-Request-Id", correlationID)
+
+		ctx := context.WithValue(req.Context(), correlationIDKey{}, correlationID)
+		next.ServeHTTP(writer, req.WithContext(ctx))
+	})
+}
+
+// LogLevelRequest is the body accepted by the /admin/loglevel endpoint.
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// Handle request to hot-reload the global log level without restarting the server.
+func SetLogLevel(writer http.ResponseWriter, req *http.Request) {
+	rawBody, err := ioutil.ReadAll(io.LimitReader(req.Body, 4096))
+	if err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+	if err := req.Body.Close(); err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+
+	var body LogLevelRequest
+	if err := json.Unmarshal(rawBody, &body); err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+
+	level, err := zerolog.ParseLevel(body.Level)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		sendJSON(writer, &HttpError{err.Error()})
+		return
+	}
+
+	zerolog.SetGlobalLevel(level)
+	loggerFromRequest(req).Info().Str("level", level.String()).Msg("log level updated")
+	SendOkResponse(req, writer, &LogLevelRequest{Level: level.String()})
+}
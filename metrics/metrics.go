@@ -0,0 +1,50 @@
+/*
+ *
+ */
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// MessagesSentTotal counts messages sent to FCM, labeled by protocol
+	// (single/batch) and outcome status (ok/error).
+	MessagesSentTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fcm_messages_sent_total",
+		Help: "Total number of messages sent to FCM, by protocol and status.",
+	}, []string{"protocol", "status"})
+
+	// SendDuration observes how long a single FCM send call takes.
+	SendDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fcm_send_duration_seconds",
+		Help:    "Duration of FCM send calls, by protocol.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"protocol"})
+
+	// RetryTotal counts messages retried from the durable outbound queue.
+	RetryTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fcm_retry_total",
+		Help: "Total number of retry attempts made for queued messages.",
+	})
+
+	// QueueDepth reports the current number of messages pending in the
+	// retry queue.
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "fcm_queue_depth",
+		Help: "Current number of messages pending in the retry queue.",
+	})
+)
+
+// ObserveSend records the outcome and duration of a send attempt for protocol.
+func ObserveSend(protocol string, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	MessagesSentTotal.WithLabelValues(protocol, status).Inc()
+	SendDuration.WithLabelValues(protocol).Observe(duration.Seconds())
+}
@@ -0,0 +1,34 @@
+/*
+ *
+ */
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveSendRecordsOkStatus(t *testing.T) {
+	before := testutil.ToFloat64(MessagesSentTotal.WithLabelValues("single", "ok"))
+
+	ObserveSend("single", 10*time.Millisecond, nil)
+
+	after := testutil.ToFloat64(MessagesSentTotal.WithLabelValues("single", "ok"))
+	if after != before+1 {
+		t.Fatalf("expected ok counter to increment by 1, went from %v to %v", before, after)
+	}
+}
+
+func TestObserveSendRecordsErrorStatus(t *testing.T) {
+	before := testutil.ToFloat64(MessagesSentTotal.WithLabelValues("batch", "error"))
+
+	ObserveSend("batch", 10*time.Millisecond, errors.New("boom"))
+
+	after := testutil.ToFloat64(MessagesSentTotal.WithLabelValues("batch", "error"))
+	if after != before+1 {
+		t.Fatalf("expected error counter to increment by 1, went from %v to %v", before, after)
+	}
+}
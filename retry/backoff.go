@@ -0,0 +1,28 @@
+/*
+ *
+ */
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// nextBackoff computes the jittered exponential backoff delay for the
+// given attempt number (0-indexed), doubling from min and capped at max,
+// following the same full-jitter pattern go-gcm used for XMPP retries.
+func nextBackoff(attempt int, min, max time.Duration) time.Duration {
+	if min <= 0 {
+		min = time.Second
+	}
+	if max <= 0 || max < min {
+		max = min
+	}
+
+	backoff := min << uint(attempt)
+	if backoff <= 0 || backoff > max {
+		backoff = max
+	}
+
+	return time.Duration(rand.Int63n(int64(backoff))) + min
+}
@@ -0,0 +1,43 @@
+/*
+ *
+ */
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBackoffWithinBounds(t *testing.T) {
+	min := 1 * time.Second
+	max := 5 * time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 50; i++ {
+			backoff := nextBackoff(attempt, min, max)
+			if backoff < min {
+				t.Fatalf("attempt %d: backoff %s below min %s", attempt, backoff, min)
+			}
+			if backoff > max+min {
+				t.Fatalf("attempt %d: backoff %s above max+min %s", attempt, backoff, max+min)
+			}
+		}
+	}
+}
+
+func TestNextBackoffDefaultsInvalidMin(t *testing.T) {
+	backoff := nextBackoff(0, 0, 0)
+	if backoff < time.Second {
+		t.Fatalf("expected backoff to fall back to the 1s default min, got %s", backoff)
+	}
+}
+
+func TestNextBackoffMaxBelowMinClampsToMin(t *testing.T) {
+	min := 5 * time.Second
+	max := 1 * time.Second
+
+	backoff := nextBackoff(3, min, max)
+	if backoff < min || backoff > 2*min {
+		t.Fatalf("expected backoff clamped around min %s, got %s", min, backoff)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ *
+ */
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rm3l/fcm-push-notification-app-server/fcm"
+	"github.com/rm3l/fcm-push-notification-app-server/metrics"
+	"github.com/rm3l/fcm-push-notification-app-server/store"
+)
+
+// Worker periodically retries queued messages that previously failed with
+// a retryable FCM error, using jittered exponential backoff.
+type Worker struct {
+	Queue       store.Queue
+	Client      *fcm.Client
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	MaxAttempts int
+	PollEvery   time.Duration
+}
+
+// NewWorker builds a Worker with the given dependencies and sane polling
+// defaults.
+func NewWorker(queue store.Queue, client *fcm.Client, minBackoff, maxBackoff time.Duration, maxAttempts int) *Worker {
+	return &Worker{
+		Queue:       queue,
+		Client:      client,
+		MinBackoff:  minBackoff,
+		MaxBackoff:  maxBackoff,
+		MaxAttempts: maxAttempts,
+		PollEvery:   1 * time.Second,
+	}
+}
+
+// Run polls the queue for due items and retries them until ctx is canceled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.PollEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.retryDue(ctx)
+		}
+	}
+}
+
+func (w *Worker) retryDue(ctx context.Context) {
+	if depth, err := w.Queue.Count(); err == nil {
+		metrics.QueueDepth.Set(float64(depth))
+	}
+
+	due, err := w.Queue.Due(time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("retry: listing due messages")
+		return
+	}
+
+	for _, item := range due {
+		metrics.RetryTotal.Inc()
+		start := time.Now()
+		res, sendErr := w.Client.Send(ctx, item.Message)
+		metrics.ObserveSend("retry", time.Since(start), sendErr)
+		if sendErr == nil {
+			if err := w.Queue.MarkDelivered(item.ID, res.Name); err != nil {
+				log.Error().Err(err).Str("id", item.ID).Msg("retry: marking message delivered")
+			}
+			continue
+		}
+
+		fcmErr, retryable := sendErr.(*fcm.Error)
+		if !retryable || !fcmErr.Retryable() || item.Attempts+1 >= w.MaxAttempts {
+			if err := w.Queue.MarkFailed(item.ID, sendErr); err != nil {
+				log.Error().Err(err).Str("id", item.ID).Msg("retry: marking message failed")
+			}
+			continue
+		}
+
+		next := time.Now().Add(nextBackoff(item.Attempts, w.MinBackoff, w.MaxBackoff))
+		if err := w.Queue.MarkRetry(item.ID, sendErr, next); err != nil {
+			log.Error().Err(err).Str("id", item.ID).Msg("retry: scheduling retry")
+		}
+	}
+}
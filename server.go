@@ -4,44 +4,99 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strings"
+	"time"
 
 	//deal with environment variables
 	"github.com/caarlos0/env"
 
-	//official GCM library
-	"github.com/google/go-gcm"
-
 	//URL router and dispatcher
 	"github.com/gorilla/mux"
 
 	//handle CORS requests
 	"github.com/rs/cors"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rm3l/fcm-push-notification-app-server/auth"
+	"github.com/rm3l/fcm-push-notification-app-server/batch"
+	"github.com/rm3l/fcm-push-notification-app-server/fcm"
+	"github.com/rm3l/fcm-push-notification-app-server/metrics"
+	"github.com/rm3l/fcm-push-notification-app-server/retry"
+	"github.com/rm3l/fcm-push-notification-app-server/store"
 )
 
 type ServerConfig struct {
 	// port the server run on. Default is 5000
 	ServerPort int `env:"SERVER_PORT" envDefault:"5000"`
 
-	// API key (from Firebase Cloud Console)
-	ApiKey string `env:"FCM_API_KEY,required"`
+	// Path to the service account JSON credentials used to authenticate
+	// against the FCM HTTP v1 API.
+	GoogleApplicationCredentials string `env:"GOOGLE_APPLICATION_CREDENTIALS,required"`
+
+	// Firebase project ID the service account belongs to.
+	FcmProjectId string `env:"FCM_PROJECT_ID,required"`
+
+	// Number of messages sent concurrently to FCM by the batch endpoint.
+	FcmBatchConcurrency int `env:"FCM_BATCH_CONCURRENCY" envDefault:"10"`
+
+	// Path to the BoltDB file backing the outbound retry queue.
+	QueueDbPath string `env:"QUEUE_DB_PATH" envDefault:"queue.db"`
+
+	// Minimum backoff delay before retrying a failed message.
+	QueueMinBackoff time.Duration `env:"QUEUE_MIN_BACKOFF" envDefault:"1s"`
+
+	// Maximum backoff delay before retrying a failed message.
+	QueueMaxBackoff time.Duration `env:"QUEUE_MAX_BACKOFF" envDefault:"5m"`
+
+	// Maximum number of delivery attempts before a queued message is marked failed.
+	QueueMaxAttempts int `env:"QUEUE_MAX_ATTEMPTS" envDefault:"10"`
+
+	// Minimum level logged: trace, debug, info, warn or error.
+	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
+
+	// Log output format: "json" (default) or "console" for human-readable output.
+	LogFormat string `env:"LOG_FORMAT" envDefault:"json"`
+
+	// Auth backend protecting /message: "static", "hmac" or "jwt".
+	AuthBackend string `env:"AUTH_BACKEND" envDefault:"static"`
+
+	// Bearer tokens accepted by the "static" auth backend.
+	AuthTokens []string `env:"AUTH_TOKENS" envSeparator:","`
+
+	// Shared secret used by the "hmac" auth backend.
+	AuthHmacSecret string `env:"AUTH_HMAC_SECRET"`
 
-	// GCM sender ID (from Firebase Cloud Console)
-	SenderId string `env:"FCM_SENDER_ID,required"`
+	// JWKS URL used by the "jwt" auth backend.
+	AuthJwksUrl string `env:"AUTH_JWKS_URL"`
+
+	// Requests allowed per second per authenticated caller.
+	RateLimitRps float64 `env:"RATE_LIMIT_RPS" envDefault:"5"`
+
+	// Burst of requests allowed per authenticated caller.
+	RateLimitBurst int `env:"RATE_LIMIT_BURST" envDefault:"10"`
+
+	// Port serving /metrics, /healthz and /readyz. 0 serves them on ServerPort instead.
+	MetricsPort int `env:"METRICS_PORT" envDefault:"0"`
 
 	//Debug mode: print logging
 	Debug bool `env:"DEBUG_MODE" envDefault:"false"`
 }
 
-type MessageStruct struct {
-	Protocol string          `json:"protocol"`
-	Message  json.RawMessage `json:"message"`
+// BatchRequest is the body accepted by the /messages:batch endpoint.
+type BatchRequest struct {
+	Messages []fcm.Message `json:"messages"`
+}
+
+// TopicSubscribersRequest is the body accepted by the topic subscribers endpoints.
+type TopicSubscribersRequest struct {
+	Tokens []string `json:"tokens"`
 }
 
 type HttpError struct {
@@ -49,13 +104,29 @@ type HttpError struct {
 }
 
 var (
-	serverConfig ServerConfig
-	port         int
-	apiKey       string
-	senderId     string
-	debug        bool
+	serverConfig  ServerConfig
+	port          int
+	debug         bool
+	fcmClient     *fcm.Client
+	queue         store.Queue
+	authenticator auth.Authenticator
+	rateLimiter   *auth.RateLimiter
 )
 
+// newAuthenticator builds the Authenticator configured via cfg.AuthBackend.
+func newAuthenticator(cfg ServerConfig) (auth.Authenticator, error) {
+	switch cfg.AuthBackend {
+	case "hmac":
+		return auth.NewHMACAuthenticator(cfg.AuthHmacSecret), nil
+	case "jwt":
+		return auth.NewJWTAuthenticator(cfg.AuthJwksUrl)
+	case "static":
+		return auth.NewStaticTokenAuthenticator(cfg.AuthTokens), nil
+	default:
+		return nil, fmt.Errorf("unknown AUTH_BACKEND %q", cfg.AuthBackend)
+	}
+}
+
 func sendJSON(writer http.ResponseWriter, obj interface{}) {
 	json.NewEncoder(writer).Encode(obj)
 }
@@ -66,82 +137,210 @@ func sendUnprocessableEntity(writer http.ResponseWriter, err error) error {
 	return json.NewEncoder(writer).Encode(err)
 }
 
-func SendOkResponse(writer http.ResponseWriter, res interface{}) {
-	log.Printf("Response: %+v", res)
+func SendOkResponse(req *http.Request, writer http.ResponseWriter, res interface{}) {
+	loggerFromRequest(req).Debug().Interface("response", res).Msg("sending response")
 	writer.WriteHeader(http.StatusOK)
 	sendJSON(writer, res)
 }
 
-func SendMessageSendError(writer http.ResponseWriter, sendErr error) {
-	log.Println("Message send error: %+v", sendErr)
-	writer.WriteHeader(http.StatusInternalServerError)
-	sendJSON(writer, sendErr)
+func SendMessageSendError(req *http.Request, writer http.ResponseWriter, sendErr error) {
+	loggerFromRequest(req).Error().Err(sendErr).Msg("message send error")
+	writer.WriteHeader(fcm.ResponseStatus(sendErr))
+	sendJSON(writer, &HttpError{sendErr.Error()})
 }
 
 // Handle request to send a new message.
 func SendMessage(writer http.ResponseWriter, req *http.Request) {
 	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
-
 	if err != nil {
-		log.Fatal(err)
+		sendUnprocessableEntity(writer, err)
+		return
 	}
 	if err := req.Body.Close(); err != nil {
-		log.Fatal(err)
+		sendUnprocessableEntity(writer, err)
+		return
 	}
 
-	// Decode the passed body into the struct.
-	var message MessageStruct
+	// Decode the passed body into the v1 message struct.
+	var message fcm.Message
 	if err := json.Unmarshal(body, &message); err != nil {
 		sendUnprocessableEntity(writer, err)
 		return
 	}
 
-	protocol := strings.ToLower(message.Protocol)
+	start := time.Now()
+	res, sendErr := fcmClient.Send(req.Context(), message)
+	metrics.ObserveSend("single", time.Since(start), sendErr)
+	if sendErr == nil {
+		SendOkResponse(req, writer, res)
+		return
+	}
 
-	if protocol == "http" {
-		// Send HTTP message
-		var httpMsg gcm.HttpMessage
-		if err := json.Unmarshal(message.Message, &httpMsg); err != nil {
-			log.Println("Message Unmarshal error: %+v", err)
-			sendUnprocessableEntity(writer, err)
+	if fcmErr, ok := sendErr.(*fcm.Error); ok && fcmErr.Retryable() {
+		item, queueErr := queue.Enqueue(message)
+		if queueErr != nil {
+			loggerFromRequest(req).Error().Err(queueErr).Msg("queueing message for retry failed")
+			SendMessageSendError(req, writer, sendErr)
 			return
 		}
+		writer.WriteHeader(http.StatusAccepted)
+		sendJSON(writer, item)
+		return
+	}
 
-		res, sendErr := gcm.SendHttp(apiKey, httpMsg)
-		if sendErr != nil {
-			SendMessageSendError(writer, sendErr)
-		} else {
-			SendOkResponse(writer, res)
-		}
-	} else if protocol == "xmpp" {
-		// Send XMPP message
-		var xmppMsg gcm.XmppMessage
-		if err := json.Unmarshal(message.Message, &xmppMsg); err != nil {
-			log.Println("Message Unmarshal error: %+v", err)
-			sendUnprocessableEntity(writer, err)
-			return
-		}
+	SendMessageSendError(req, writer, sendErr)
+}
 
-		res, _, sendErr := gcm.SendXmpp(senderId, apiKey, xmppMsg)
-		if sendErr != nil {
-			SendMessageSendError(writer, sendErr)
-		} else {
-			SendOkResponse(writer, res)
-		}
-	} else {
-		// Error
+// Handle request to report the delivery state of a previously queued message.
+func GetMessage(writer http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+
+	item, err := queue.Get(id)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		sendJSON(writer, &HttpError{err.Error()})
+		return
+	}
+	if item == nil {
+		writer.WriteHeader(http.StatusNotFound)
+		sendJSON(writer, &HttpError{fmt.Sprintf("no message queued with id %s", id)})
+		return
+	}
+
+	SendOkResponse(req, writer, item)
+}
+
+// Handle request to send up to batch.MaxMessages messages in one call.
+func SendBatch(writer http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 10*1048576))
+	if err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+	if err := req.Body.Close(); err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+
+	var batchReq BatchRequest
+	if err := json.Unmarshal(body, &batchReq); err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+
+	results, err := batch.Send(req.Context(), fcmClient, batchReq.Messages, serverConfig.FcmBatchConcurrency)
+	if err != nil {
 		writer.WriteHeader(http.StatusBadRequest)
-		sendJSON(writer, &HttpError{"protocol should be HTTP or XMPP only."})
+		sendJSON(writer, &HttpError{err.Error()})
+		return
+	}
+
+	SendOkResponse(req, writer, results)
+}
+
+// Handle request to subscribe a set of registration tokens to a topic.
+func SubscribeToTopic(writer http.ResponseWriter, req *http.Request) {
+	handleTopicSubscribers(writer, req, fcmClient.SubscribeToTopic)
+}
+
+// Handle request to unsubscribe a set of registration tokens from a topic.
+func UnsubscribeFromTopic(writer http.ResponseWriter, req *http.Request) {
+	handleTopicSubscribers(writer, req, fcmClient.UnsubscribeFromTopic)
+}
+
+func handleTopicSubscribers(writer http.ResponseWriter, req *http.Request, call func(context.Context, string, []string) ([]fcm.TokenResult, error)) {
+	topic := mux.Vars(req)["topic"]
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+	if err := req.Body.Close(); err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+
+	var subsReq TopicSubscribersRequest
+	if err := json.Unmarshal(body, &subsReq); err != nil {
+		sendUnprocessableEntity(writer, err)
+		return
+	}
+
+	results, err := call(req.Context(), topic, subsReq.Tokens)
+	if err != nil {
+		SendMessageSendError(req, writer, err)
+		return
+	}
+
+	SendOkResponse(req, writer, results)
+}
+
+// Handle liveness probes: cheap, always OK once the process is up.
+func Healthz(writer http.ResponseWriter, req *http.Request) {
+	writer.WriteHeader(http.StatusOK)
+}
+
+// Handle readiness probes: OK only once the server can acquire an FCM OAuth2 token.
+func Readyz(writer http.ResponseWriter, req *http.Request) {
+	if err := fcmClient.Ready(req.Context()); err != nil {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		sendJSON(writer, &HttpError{err.Error()})
+		return
 	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+// AdminHandler serves /metrics, /healthz and /readyz.
+func AdminHandler() http.Handler {
+	router := mux.NewRouter()
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	router.HandleFunc("/healthz", Healthz).Methods("GET")
+	router.HandleFunc("/readyz", Readyz).Methods("GET")
+	return router
 }
 
 // Route handler for the server
 func Handler() http.Handler {
 	router := mux.NewRouter()
+	router.Use(correlationIDMiddleware)
 
 	// POST /message
-	// Send a new message
-	router.HandleFunc("/message", SendMessage).Methods("POST")
+	// Send a new message, guarded by the configured auth backend and per-caller rate limit
+	router.Handle("/message", auth.Middleware(authenticator, rateLimiter)(http.HandlerFunc(SendMessage))).Methods("POST")
+
+	// POST /messages:batch
+	// Send up to batch.MaxMessages messages in a single request, guarded by the
+	// configured auth backend and per-caller rate limit
+	router.Handle("/messages:batch", auth.Middleware(authenticator, rateLimiter)(http.HandlerFunc(SendBatch))).Methods("POST")
+
+	// GET /messages/{id}
+	// Report the delivery state of a previously queued message, guarded by the
+	// configured auth backend and per-caller rate limit
+	router.Handle("/messages/{id}", auth.Middleware(authenticator, rateLimiter)(http.HandlerFunc(GetMessage))).Methods("GET")
+
+	// POST /topics/{topic}/subscribers
+	// Subscribe registration tokens to a topic, guarded by the configured auth
+	// backend and per-caller rate limit
+	router.Handle("/topics/{topic}/subscribers", auth.Middleware(authenticator, rateLimiter)(http.HandlerFunc(SubscribeToTopic))).Methods("POST")
+
+	// DELETE /topics/{topic}/subscribers
+	// Unsubscribe registration tokens from a topic, guarded by the configured
+	// auth backend and per-caller rate limit
+	router.Handle("/topics/{topic}/subscribers", auth.Middleware(authenticator, rateLimiter)(http.HandlerFunc(UnsubscribeFromTopic))).Methods("DELETE")
+
+	// POST /admin/loglevel
+	// Hot-reload the global log level, guarded by the configured auth backend
+	// and per-caller rate limit
+	router.Handle("/admin/loglevel", auth.Middleware(authenticator, rateLimiter)(http.HandlerFunc(SetLogLevel))).Methods("POST")
+
+	if serverConfig.MetricsPort == 0 {
+		// Serve /metrics, /healthz and /readyz on the same port when no
+		// separate METRICS_PORT is configured.
+		router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+		router.HandleFunc("/healthz", Healthz).Methods("GET")
+		router.HandleFunc("/readyz", Readyz).Methods("GET")
+	}
 
 	corsConfig := cors.New(cors.Options{
 		AllowCredentials: true,
@@ -151,7 +350,7 @@ func Handler() http.Handler {
 
 func main() {
 
-	serverConfig := ServerConfig{}
+	serverConfig = ServerConfig{}
 
 	configErr := env.Parse(&serverConfig)
 	if configErr != nil {
@@ -159,16 +358,50 @@ func main() {
 	}
 
 	port = serverConfig.ServerPort
-	apiKey = serverConfig.ApiKey
-	senderId = serverConfig.SenderId
 	debug = serverConfig.Debug
 
-	gcm.DebugMode = debug
+	logger := initLogger(serverConfig)
+
+	client, clientErr := fcm.NewClient(context.Background(), serverConfig.GoogleApplicationCredentials, serverConfig.FcmProjectId)
+	if clientErr != nil {
+		logger.Fatal().Err(clientErr).Msg("failed to initialize FCM client")
+	}
+	fcmClient = client
+
+	boltQueue, queueErr := store.NewBoltQueue(serverConfig.QueueDbPath)
+	if queueErr != nil {
+		logger.Fatal().Err(queueErr).Msg("failed to open retry queue")
+	}
+	defer boltQueue.Close()
+	queue = boltQueue
+
+	worker := retry.NewWorker(queue, fcmClient, serverConfig.QueueMinBackoff, serverConfig.QueueMaxBackoff, serverConfig.QueueMaxAttempts)
+	go worker.Run(context.Background())
+
+	a, authErr := newAuthenticator(serverConfig)
+	if authErr != nil {
+		logger.Fatal().Err(authErr).Msg("failed to initialize auth backend")
+	}
+	authenticator = a
+	rateLimiter = auth.NewRateLimiter(serverConfig.RateLimitRps, serverConfig.RateLimitBurst)
+
+	if debug {
+		logger.Debug().Msg("debug mode enabled")
+	}
+
+	if serverConfig.MetricsPort != 0 {
+		go func() {
+			adminErr := http.ListenAndServe(fmt.Sprintf(":%v", serverConfig.MetricsPort), AdminHandler())
+			if adminErr != nil {
+				logger.Fatal().Err(adminErr).Msg("admin ListenAndServe failed")
+			}
+		}()
+	}
 
 	//Start the server
 	err := http.ListenAndServe(fmt.Sprintf(":%v", port), Handler())
 	if err != nil {
-		log.Fatal("ListenAndServe: " + err.Error())
+		logger.Fatal().Err(err).Msg("ListenAndServe failed")
 	}
-	log.Println(fmt.Sprintf("Started - serving at port %v", port))
+	logger.Info().Int("port", port).Msg("server started")
 }
@@ -0,0 +1,168 @@
+/*
+ *
+ */
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/rm3l/fcm-push-notification-app-server/fcm"
+)
+
+var itemsBucket = []byte("items")
+
+// BoltQueue is a Queue backed by a local BoltDB file.
+type BoltQueue struct {
+	db *bolt.DB
+}
+
+// NewBoltQueue opens (creating if necessary) a BoltDB-backed Queue at path.
+func NewBoltQueue(path string) (*BoltQueue, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("store: opening bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(itemsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: initializing bucket: %w", err)
+	}
+
+	return &BoltQueue{db: db}, nil
+}
+
+func (q *BoltQueue) Enqueue(msg fcm.Message) (*Item, error) {
+	item := &Item{
+		ID:          uuid.NewString(),
+		Message:     msg,
+		State:       StatePending,
+		NextAttempt: time.Now(),
+	}
+	if err := q.put(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (q *BoltQueue) Get(id string) (*Item, error) {
+	var item *Item
+	err := q.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(itemsBucket).Get([]byte(id))
+		if raw == nil {
+			return nil
+		}
+		item = &Item{}
+		return json.Unmarshal(raw, item)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store: getting item %s: %w", id, err)
+	}
+	return item, nil
+}
+
+func (q *BoltQueue) Due(now time.Time) ([]*Item, error) {
+	var due []*Item
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(itemsBucket).ForEach(func(_, raw []byte) error {
+			item := &Item{}
+			if err := json.Unmarshal(raw, item); err != nil {
+				return err
+			}
+			if item.State == StatePending && !item.NextAttempt.After(now) {
+				due = append(due, item)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store: listing due items: %w", err)
+	}
+	return due, nil
+}
+
+func (q *BoltQueue) Count() (int, error) {
+	count := 0
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(itemsBucket).ForEach(func(_, raw []byte) error {
+			item := &Item{}
+			if err := json.Unmarshal(raw, item); err != nil {
+				return err
+			}
+			if item.State == StatePending {
+				count++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("store: counting pending items: %w", err)
+	}
+	return count, nil
+}
+
+func (q *BoltQueue) MarkDelivered(id string, resultName string) error {
+	return q.update(id, func(item *Item) {
+		item.State = StateDelivered
+		item.ResultName = resultName
+		item.LastError = ""
+	})
+}
+
+func (q *BoltQueue) MarkRetry(id string, attemptErr error, nextAttempt time.Time) error {
+	return q.update(id, func(item *Item) {
+		item.Attempts++
+		item.NextAttempt = nextAttempt
+		if attemptErr != nil {
+			item.LastError = attemptErr.Error()
+		}
+	})
+}
+
+func (q *BoltQueue) MarkFailed(id string, attemptErr error) error {
+	return q.update(id, func(item *Item) {
+		item.State = StateFailed
+		item.Attempts++
+		if attemptErr != nil {
+			item.LastError = attemptErr.Error()
+		}
+	})
+}
+
+func (q *BoltQueue) Close() error {
+	return q.db.Close()
+}
+
+func (q *BoltQueue) put(item *Item) error {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("store: marshaling item %s: %w", item.ID, err)
+	}
+	err = q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(itemsBucket).Put([]byte(item.ID), raw)
+	})
+	if err != nil {
+		return fmt.Errorf("store: persisting item %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+func (q *BoltQueue) update(id string, mutate func(item *Item)) error {
+	item, err := q.Get(id)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return fmt.Errorf("store: item %s not found", id)
+	}
+	mutate(item)
+	return q.put(item)
+}
@@ -0,0 +1,185 @@
+/*
+ *
+ */
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rm3l/fcm-push-notification-app-server/fcm"
+)
+
+func newTestQueue(t *testing.T) *BoltQueue {
+	t.Helper()
+	q, err := NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func TestBoltQueueEnqueueAndGet(t *testing.T) {
+	q := newTestQueue(t)
+
+	item, err := q.Enqueue(fcm.Message{Token: "abc"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if item.State != StatePending {
+		t.Fatalf("expected state %s, got %s", StatePending, item.State)
+	}
+
+	got, err := q.Get(item.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got == nil || got.ID != item.ID {
+		t.Fatalf("expected to find item %s, got %+v", item.ID, got)
+	}
+}
+
+func TestBoltQueueGetMissingReturnsNil(t *testing.T) {
+	q := newTestQueue(t)
+
+	got, err := q.Get("does-not-exist")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil for missing item, got %+v", got)
+	}
+}
+
+func TestBoltQueueMarkRetrySchedulesNextAttempt(t *testing.T) {
+	q := newTestQueue(t)
+
+	item, err := q.Enqueue(fcm.Message{Token: "abc"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	next := time.Now().Add(time.Minute)
+	if err := q.MarkRetry(item.ID, errors.New("transient"), next); err != nil {
+		t.Fatalf("MarkRetry: %v", err)
+	}
+
+	got, err := q.Get(item.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.State != StatePending {
+		t.Fatalf("expected state to remain %s, got %s", StatePending, got.State)
+	}
+	if got.Attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got.Attempts)
+	}
+	if got.LastError != "transient" {
+		t.Fatalf("expected last error %q, got %q", "transient", got.LastError)
+	}
+
+	due, err := q.Due(time.Now())
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("expected no due items before next attempt, got %d", len(due))
+	}
+
+	due, err = q.Due(next.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != item.ID {
+		t.Fatalf("expected item %s to be due, got %+v", item.ID, due)
+	}
+}
+
+func TestBoltQueueCountOnlyCountsPending(t *testing.T) {
+	q := newTestQueue(t)
+
+	pending, err := q.Enqueue(fcm.Message{Token: "abc"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	failed, err := q.Enqueue(fcm.Message{Token: "def"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q.MarkFailed(failed.ID, errors.New("permanent")); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	count, err := q.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 pending item, got %d", count)
+	}
+
+	if err := q.MarkDelivered(pending.ID, "projects/p/messages/1"); err != nil {
+		t.Fatalf("MarkDelivered: %v", err)
+	}
+	count, err = q.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected 0 pending items after delivery, got %d", count)
+	}
+}
+
+func TestBoltQueueMarkFailedSetsState(t *testing.T) {
+	q := newTestQueue(t)
+
+	item, err := q.Enqueue(fcm.Message{Token: "abc"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if err := q.MarkFailed(item.ID, errors.New("permanent")); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	got, err := q.Get(item.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.State != StateFailed {
+		t.Fatalf("expected state %s, got %s", StateFailed, got.State)
+	}
+}
+
+func TestBoltQueueMarkDeliveredClearsLastError(t *testing.T) {
+	q := newTestQueue(t)
+
+	item, err := q.Enqueue(fcm.Message{Token: "abc"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q.MarkRetry(item.ID, errors.New("transient"), time.Now()); err != nil {
+		t.Fatalf("MarkRetry: %v", err)
+	}
+
+	if err := q.MarkDelivered(item.ID, "projects/p/messages/1"); err != nil {
+		t.Fatalf("MarkDelivered: %v", err)
+	}
+
+	got, err := q.Get(item.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.State != StateDelivered {
+		t.Fatalf("expected state %s, got %s", StateDelivered, got.State)
+	}
+	if got.LastError != "" {
+		t.Fatalf("expected last error cleared, got %q", got.LastError)
+	}
+	if got.ResultName != "projects/p/messages/1" {
+		t.Fatalf("expected result name to be recorded, got %q", got.ResultName)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ *
+ */
+package store
+
+import (
+	"time"
+
+	"github.com/rm3l/fcm-push-notification-app-server/fcm"
+)
+
+// State is the delivery state of a queued message.
+type State string
+
+const (
+	StatePending   State = "pending"
+	StateDelivered State = "delivered"
+	StateFailed    State = "failed"
+)
+
+// Item is a single message tracked by a Queue, from first enqueue through
+// its final delivered/failed outcome.
+type Item struct {
+	ID          string      `json:"id"`
+	Message     fcm.Message `json:"message"`
+	State       State       `json:"state"`
+	Attempts    int         `json:"attempts"`
+	NextAttempt time.Time   `json:"next_attempt"`
+	LastError   string      `json:"last_error,omitempty"`
+	ResultName  string      `json:"result_name,omitempty"`
+}
+
+// Queue is a durable store for messages that could not be sent to FCM
+// immediately and are awaiting retry.
+type Queue interface {
+	// Enqueue persists msg as a new pending Item and returns its ID.
+	Enqueue(msg fcm.Message) (*Item, error)
+
+	// Get returns the Item with the given ID, or nil if it doesn't exist.
+	Get(id string) (*Item, error)
+
+	// Due returns all pending items whose NextAttempt is at or before now.
+	Due(now time.Time) ([]*Item, error)
+
+	// Count returns the number of items currently pending delivery.
+	Count() (int, error)
+
+	// MarkDelivered records a successful delivery for the item with the given ID.
+	MarkDelivered(id string, resultName string) error
+
+	// MarkRetry records a failed attempt and schedules the next one for nextAttempt.
+	MarkRetry(id string, attemptErr error, nextAttempt time.Time) error
+
+	// MarkFailed records that an item exhausted its retry attempts.
+	MarkFailed(id string, attemptErr error) error
+
+	// Close releases any resources held by the queue.
+	Close() error
+}